@@ -1,15 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"runtime/pprof"
-	"sync"
+	"runtime"
 	"time"
 
 	"github.com/staD020/TSCrunch"
+	"github.com/staD020/TSCrunch/internal/cliprofile"
 )
 
 func usage() {
@@ -19,6 +20,10 @@ func usage() {
 	fmt.Println(" -p  : input file is a prg, first 2 bytes are discarded.")
 	fmt.Println(" -i  : inplace crunching (forces -p)")
 	fmt.Println(" -q  : quiet mode")
+	fmt.Println(" -s  : print periodic per-file progress stats")
+	fmt.Println(" -s-interval duration: sampling interval for -s (default 1s)")
+	fmt.Println(" -j  N: max files crunched concurrently (default runtime.NumCPU())")
+	fmt.Println(" -cpuprofile/-memprofile/-blockprofile file, -memprofilerate N: pprof profiling")
 }
 
 func main() {
@@ -33,73 +38,43 @@ func main() {
 func run() error {
 	t0 := time.Now()
 	opt := TSCrunch.Options{SkipRLE: true}
-	var cpuProfile string
-	flag.StringVar(&cpuProfile, "cpuprofile", "", "write cpu profile to `file`")
+	var prof cliprofile.Flags
+	prof.Register()
 	flag.BoolVar(&opt.PRG, "p", false, "")
 	flag.BoolVar(&opt.QUIET, "q", false, "")
 	flag.BoolVar(&opt.INPLACE, "i", false, "")
+	var stats bool
+	flag.BoolVar(&stats, "s", false, "")
+	var statsInterval time.Duration
+	flag.DurationVar(&statsInterval, "s-interval", time.Second, "")
+	var concurrency int
+	flag.IntVar(&concurrency, "j", runtime.NumCPU(), "")
 	flag.Usage = usage
 	flag.Parse()
 
-	if cpuProfile != "" {
-		f, err := os.Create(cpuProfile)
-		if err != nil {
-			return fmt.Errorf("could not create CPU profile %q: %w", cpuProfile, err)
-		}
-		defer f.Close()
-		if err := pprof.StartCPUProfile(f); err != nil {
-			return fmt.Errorf("could not start CPU profile: %w", err)
-		}
-		defer pprof.StopCPUProfile()
+	stopProfiling, err := prof.Start()
+	if err != nil {
+		return err
 	}
+	defer stopProfiling()
 
 	inFiles := flag.Args()
 	if len(inFiles) < 1 {
 		return fmt.Errorf("not enough args")
 	}
 
-	crunchFiles(opt, inFiles)
+	if stats {
+		opt.ProgressInterval = statsInterval
+		opt.Progress = func(s TSCrunch.FileStats) {
+			fmt.Printf("%q: pos %d (+%d) tokens %d (+%d)\n", s.File, s.Pos, s.PosDelta, s.Tokens, s.TokensDelta)
+		}
+	}
+	if err := TSCrunch.CrunchFiles(context.Background(), opt, inFiles, concurrency); err != nil {
+		return err
+	}
 
 	if !opt.QUIET {
 		fmt.Printf("elapsed: %s\n", time.Since(t0))
 	}
 	return nil
 }
-
-func crunchFiles(opt TSCrunch.Options, ff []string) {
-	wg := &sync.WaitGroup{}
-	wg.Add(len(ff))
-	for _, file := range ff {
-		go func(file string) {
-			defer wg.Done()
-			t1 := time.Now()
-			in, err := os.Open(file)
-			if err != nil {
-				log.Printf("error: %v\n", err)
-				return
-			}
-			defer in.Close()
-			t, err := TSCrunch.New(opt, in)
-			if err != nil {
-				log.Printf("error: %v\n", err)
-				return
-			}
-			f, err := os.Create(file + ".lz")
-			if err != nil {
-				log.Printf("error: %v\n", err)
-				return
-			}
-			defer f.Close()
-			_, err = t.WriteTo(f)
-			if err != nil {
-				log.Printf("error: %v\n", err)
-				return
-			}
-
-			if !opt.QUIET {
-				fmt.Printf("crunching %q took %s\n\n", file, time.Since(t1))
-			}
-		}(file)
-	}
-	wg.Wait()
-}
@@ -9,55 +9,59 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"runtime/pprof"
 	"time"
 
 	"github.com/staD020/TSCrunch"
+	"github.com/staD020/TSCrunch/internal/cliprofile"
 )
 
 func usage() {
 	fmt.Printf("TSCrunch %s - binary cruncher, by Antonio Savona\n", TSCrunch.Version)
 	fmt.Println("Fast mode by burg")
-	fmt.Println("Usage: tscrunch [-p] [-i] [-f] [-q] [-x $081f|0x081f|2079] infile outfile")
+	fmt.Println("Usage: tscrunch [-p] [-i] [-f] [-q] [-x $081f|0x081f|2079] [-t platform] infile outfile")
 	fmt.Println(" -p  : input file is a prg, first 2 bytes are discarded.")
 	fmt.Println(" -x  $addr: creates a self extracting file (forces -p)")
+	fmt.Println(" -t  platform: target for -x, one of c64, c128, vic20+8k, vic20+24k, plus4, pet (default c64)")
 	fmt.Println(" -i  : inplace crunching (forces -p)")
 	fmt.Println(" -q  : quiet mode")
 	fmt.Println(" -f  : fast mode")
+	fmt.Println(" -cpuprofile/-memprofile/-blockprofile file, -memprofilerate N: pprof profiling")
 }
 
 func main() {
 	if err := run(); err != nil {
 		log.Printf("error: %v\n", err)
 		usage()
-		return
+		os.Exit(1)
 	}
 }
 
 func run() error {
 	t0 := time.Now()
 	opt := TSCrunch.Options{STATS: true}
-	var cpuProfile string
-	flag.StringVar(&cpuProfile, "cpuprofile", "", "write cpu profile to `file`")
+	var prof cliprofile.Flags
+	prof.Register()
 	flag.BoolVar(&opt.PRG, "p", false, "")
 	flag.BoolVar(&opt.QUIET, "q", false, "")
 	flag.BoolVar(&opt.INPLACE, "i", false, "")
 	flag.BoolVar(&opt.Fast, "f", false, "")
 	flag.StringVar(&opt.JumpTo, "x", "", "")
+	var platform string
+	flag.StringVar(&platform, "t", "", "")
 	flag.Usage = usage
 	flag.Parse()
 
-	if cpuProfile != "" {
-		f, err := os.Create(cpuProfile)
-		if err != nil {
-			return fmt.Errorf("could not create CPU profile %q: %w", cpuProfile, err)
-		}
-		defer f.Close()
-		if err := pprof.StartCPUProfile(f); err != nil {
-			return fmt.Errorf("could not start CPU profile: %w", err)
-		}
-		defer pprof.StopCPUProfile()
+	p, err := TSCrunch.ParsePlatform(platform)
+	if err != nil {
+		return err
 	}
+	opt.Platform = p
+
+	stopProfiling, err := prof.Start()
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
 
 	if flag.NArg() != 2 {
 		return fmt.Errorf("not enough args")
@@ -84,6 +88,12 @@ func run() error {
 		return err
 	}
 	if !opt.QUIET {
+		if t.PRG() {
+			start, end := t.DecrunchRange()
+			fmt.Printf("Decrunch to $%04x-$%04x\n", start, end)
+		}
+		fmt.Printf("Crunched to %.2f%% of original size (%d -> %d bytes)\n",
+			100*float64(t.CrunchedLen())/float64(t.SourceLen()), t.SourceLen(), t.CrunchedLen())
 		fmt.Printf("elapsed: %s\n", time.Since(t0))
 	}
 	return nil
@@ -0,0 +1,139 @@
+package TSCrunch
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Decode decrunches the token stream produced by a plain (non-SFX,
+// non-inplace) crunch: a 1-byte (optimalRun-1) header followed by tokens
+// in the format tokenPayload emits, terminated by TERMINATOR. It is the
+// inverse of New/Writer for that common case.
+//
+// SFX output embeds a 6502 boot stub ahead of the stream instead of the
+// header byte; it is meant to be decrunched on (emulated) hardware, not by
+// this function. INPLACE output wraps the stream in its own addressing
+// header; use DecodeInplace for that.
+func Decode(src []byte) ([]byte, error) {
+	if len(src) < 1 {
+		return nil, errors.New("tscrunch: empty input")
+	}
+	optimalRun := int(src[0]) + 1
+	out, _, err := decodeTokens(src[1:], optimalRun)
+	return out, err
+}
+
+// DecodeInplace decrunches the format produced by Options.INPLACE: a
+// 2-byte load address, the original 2-byte PRG start address, the
+// optimal-run header, and the token stream flanked by a single remainder
+// byte (the tail that didn't fit in the in-place safety margin). It
+// returns the original PRG bytes, start address included.
+func DecodeInplace(src []byte) ([]byte, error) {
+	if len(src) < 6 {
+		return nil, errors.New("tscrunch: inplace input too short")
+	}
+	addr := src[2:4]
+	optimalRun := int(src[4]) + 1
+	remainder0 := src[5]
+	body, consumed, err := decodeTokens(src[6:], optimalRun)
+	if err != nil {
+		return nil, err
+	}
+	tail := src[6+consumed:]
+	out := make([]byte, 0, 2+len(body)+1+len(tail))
+	out = append(out, addr...)
+	out = append(out, body...)
+	out = append(out, remainder0)
+	out = append(out, tail...)
+	return out, nil
+}
+
+// decodeTokens parses the token stream in src until TERMINATOR, returning
+// the decrunched bytes and the number of src bytes consumed, TERMINATOR
+// included.
+func decodeTokens(src []byte, optimalRun int) ([]byte, int, error) {
+	var out []byte
+	i := 0
+	for {
+		if i >= len(src) {
+			return nil, 0, errors.New("tscrunch: truncated stream, missing terminator")
+		}
+		b := src[i]
+		switch {
+		case b == TERMINATOR:
+			return out, i + 1, nil
+		case b < TERMINATOR:
+			// Literal: LITERALMASK|size, followed by size raw bytes.
+			size := int(b)
+			i++
+			if i+size > len(src) {
+				return nil, 0, errors.New("tscrunch: truncated literal")
+			}
+			out = append(out, src[i:i+size]...)
+			i += size
+		case b < 0x80:
+			// LZ2: LZ2MASK | (0x7f - offset), size is always 2.
+			offset := 0x7f - int(b)
+			if offset < 1 || offset > len(out) {
+				return nil, 0, fmt.Errorf("tscrunch: invalid LZ2 offset %d", offset)
+			}
+			i++
+			p := len(out) - offset
+			out = append(out, out[p], out[p+1])
+		case b == RLEMASK:
+			// Bare RLEMASK: zero run, length is the optimalRun header value.
+			i++
+			out = append(out, make([]byte, optimalRun)...)
+		case b&1 == 1:
+			// RLE: RLEMASK | ((size-1)<<1), followed by the repeated byte.
+			size := int((b>>1)&0x3f) + 1
+			i++
+			if i >= len(src) {
+				return nil, 0, errors.New("tscrunch: truncated RLE token")
+			}
+			rlebyte := src[i]
+			i++
+			for k := 0; k < size; k++ {
+				out = append(out, rlebyte)
+			}
+		case b&0x02 != 0:
+			// Short LZ: LZMASK | ((size-1)<<2) | 2, followed by a 1-byte offset.
+			size := int((b>>2)&0x1f) + 1
+			i++
+			if i >= len(src) {
+				return nil, 0, errors.New("tscrunch: truncated LZ token")
+			}
+			offset := int(src[i])
+			i++
+			if offset < 1 || offset > len(out) {
+				return nil, 0, fmt.Errorf("tscrunch: invalid LZ offset %d", offset)
+			}
+			p := len(out) - offset
+			for k := 0; k < size; k++ {
+				out = append(out, out[p+k])
+			}
+		default:
+			// LONGLZ: LZMASK | (((size-1)>>1)<<2), followed by a 2-byte
+			// negative offset with the size's LSB packed into the MSB of
+			// the high byte.
+			i++
+			if i+1 >= len(src) {
+				return nil, 0, errors.New("tscrunch: truncated LONGLZ token")
+			}
+			lo := int(src[i])
+			hi := int(src[i+1])
+			i += 2
+			size := ((int(b)>>2)&0x1f)<<1 | ((hi >> 7) & 1)
+			size++
+			v15 := ((hi & 0x7f) << 8) | lo
+			offset := 0x8000 - v15
+			if offset < 1 || offset > len(out) {
+				return nil, 0, fmt.Errorf("tscrunch: invalid LONGLZ offset %d", offset)
+			}
+			p := len(out) - offset
+			for k := 0; k < size; k++ {
+				out = append(out, out[p+k])
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package cliprofile wires the pprof flags the TSCrunch CLI wrappers
+// share (-cpuprofile, -memprofile, -blockprofile, -memprofilerate) into a
+// single Flags type, so the profiles a long crunch is producing still land
+// on disk if the user interrupts it with SIGINT instead of waiting for it
+// to finish.
+package cliprofile
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Flags holds the profiling flags every cmd/* main registers alongside its
+// own. Zero value means "profiling off".
+type Flags struct {
+	CPUProfile   string
+	MemProfile   string
+	BlockProfile string
+	MemRate      int
+}
+
+// Register adds -cpuprofile, -memprofile, -blockprofile and
+// -memprofilerate to the default flag set.
+func (f *Flags) Register() {
+	flag.StringVar(&f.CPUProfile, "cpuprofile", "", "write cpu profile to `file`")
+	flag.StringVar(&f.MemProfile, "memprofile", "", "write heap profile to `file`")
+	flag.StringVar(&f.BlockProfile, "blockprofile", "", "write block profile to `file`")
+	flag.IntVar(&f.MemRate, "memprofilerate", 0, "set runtime.MemProfileRate (0 keeps the default)")
+}
+
+// Start applies f and, if any profile was requested, begins CPU/block
+// profiling and installs a SIGINT handler that flushes every requested
+// profile before the process exits. The caller must defer the returned
+// stop func to flush profiles on a normal return too; stop is a no-op if
+// nothing was requested.
+func (f *Flags) Start() (stop func(), err error) {
+	if f.MemRate > 0 {
+		runtime.MemProfileRate = f.MemRate
+	}
+	if f.BlockProfile != "" {
+		runtime.SetBlockProfileRate(1)
+	}
+
+	var cpuFile *os.File
+	if f.CPUProfile != "" {
+		cpuFile, err = os.Create(f.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("could not create CPU profile %q: %w", f.CPUProfile, err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			return nil, fmt.Errorf("could not start CPU profile: %w", err)
+		}
+	}
+
+	write := func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if f.MemProfile != "" {
+			if mf, err := os.Create(f.MemProfile); err != nil {
+				log.Printf("error: could not create memory profile %q: %v\n", f.MemProfile, err)
+			} else {
+				runtime.GC()
+				pprof.Lookup("heap").WriteTo(mf, 0)
+				mf.Close()
+			}
+		}
+		if f.BlockProfile != "" {
+			if bf, err := os.Create(f.BlockProfile); err != nil {
+				log.Printf("error: could not create block profile %q: %v\n", f.BlockProfile, err)
+			} else {
+				pprof.Lookup("block").WriteTo(bf, 0)
+				bf.Close()
+			}
+		}
+	}
+
+	if f.CPUProfile != "" || f.MemProfile != "" || f.BlockProfile != "" {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, os.Interrupt)
+		go func() {
+			<-sigc
+			write()
+			os.Exit(1)
+		}()
+	}
+	return write, nil
+}
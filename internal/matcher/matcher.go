@@ -0,0 +1,53 @@
+// Package matcher implements the hot inner loops of TSCrunch's optimal
+// parser: extending a candidate LZ match as far as it goes, and filtering
+// a prefix-array bucket down to the offsets that are still valid matches
+// at the current position. Both were the top two entries in CPU profiles
+// of crunch() on ~64KB inputs, so amd64 gets SIMD back-ends (AVX2, with an
+// SSE2 fallback); every other GOARCH uses the plain Go loop.
+package matcher
+
+import "bytes"
+
+// MatchLen returns the number of leading bytes a and b have in common, up
+// to max (and never more than min(len(a), len(b))).
+func MatchLen(a, b []byte, max int) int {
+	if max > len(a) {
+		max = len(a)
+	}
+	if max > len(b) {
+		max = len(b)
+	}
+	if max <= 0 {
+		return 0
+	}
+	return matchLenArch(a[:max], b[:max])
+}
+
+// FindCandidates scans positions (a bucket of earlier offsets sharing the
+// same MINLZ-byte key, sorted ascending) for ones that are valid LZ match
+// starts at i: strictly before i, newer than minOffset, and matching data
+// for the whole of key (not just the bucket's key prefix). It walks
+// positions back-to-front, i.e. from the closest offset to i outward,
+// which is the order the caller's binary search leaves them in, and the
+// order LZ's first-found tie-break relies on. It stops as soon as a
+// position is no longer newer than minOffset, since positions is sorted
+// and everything before it is even older. It writes at most len(out)
+// matches to out and returns how many it found.
+func FindCandidates(data []byte, key []byte, positions []int, i, minOffset int, out []int32) int {
+	n := 0
+	for o := len(positions) - 1; o >= 0 && n < len(out); o-- {
+		p := positions[o]
+		if p <= minOffset {
+			break
+		}
+		if p >= i || p+len(key) > len(data) {
+			continue
+		}
+		if !bytes.Equal(data[p:p+len(key)], key) {
+			continue
+		}
+		out[n] = int32(p)
+		n++
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package matcher
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMatchLen(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 33, 63, 64, 65, 200} {
+		a := make([]byte, n+8)
+		rnd.Read(a)
+		b := append([]byte{}, a...)
+		for want := 0; want <= n; want++ {
+			bb := append([]byte{}, b...)
+			if want < len(bb) {
+				bb[want] ^= 0xFF // force the first mismatch at `want`
+			}
+			got := MatchLen(a, bb, n)
+			if want == n {
+				// no mismatch was introduced inside [0,n)
+				if got != n {
+					t.Fatalf("n=%d want=%d: got %d", n, want, got)
+				}
+				continue
+			}
+			if got != want {
+				t.Fatalf("n=%d want=%d: got %d", n, want, got)
+			}
+		}
+	}
+}
+
+func TestMatchLenBounds(t *testing.T) {
+	a := []byte("hello world")
+	b := []byte("hello there")
+	if got := MatchLen(a, b, 100); got != 6 {
+		t.Fatalf("got %d, want 6", got)
+	}
+	if got := MatchLen(a, b, 3); got != 3 {
+		t.Fatalf("got %d, want 3", got)
+	}
+}
+
+func TestFindCandidates(t *testing.T) {
+	data := []byte("abcXYZabcdefabcXYZghiabc")
+	// "abc" occurs at 0, 6, 12, 21.
+	positions := []int{0, 6, 12, 21}
+	out := make([]int32, len(positions))
+	n := FindCandidates(data, []byte("abc"), positions, 21, -1, out)
+	got := out[:n]
+	want := []int32{12, 6, 0} // nearest-to-farthest, i.e. scanned back-to-front
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package matcher
+
+import "golang.org/x/sys/cpu"
+
+var (
+	hasAVX2 = cpu.X86.HasAVX2
+	hasSSE2 = cpu.X86.HasSSE2
+)
+
+// matchLenAVX2 and matchLenSSE2 are hand-written (avo-style) assembly:
+// compare 32 (AVX2) or 16 (SSE2) bytes at a time with a packed-compare +
+// move-mask + bit-scan, falling back to a byte-at-a-time tail. Both
+// assume n == len(a) == len(b) and n > 0; matchLenArch only calls them
+// once it has picked a vector width the slices are long enough for.
+//
+//go:noescape
+func matchLenAVX2(a, b *byte, n int) int
+
+//go:noescape
+func matchLenSSE2(a, b *byte, n int) int
+
+func matchLenArch(a, b []byte) int {
+	n := len(a)
+	switch {
+	case hasAVX2 && n >= 32:
+		return matchLenAVX2(&a[0], &b[0], n)
+	case hasSSE2 && n >= 16:
+		return matchLenSSE2(&a[0], &b[0], n)
+	default:
+		return matchLenGeneric(a, b)
+	}
+}
+
+func matchLenGeneric(a, b []byte) int {
+	n := 0
+	for n < len(a) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
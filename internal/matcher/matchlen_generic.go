@@ -0,0 +1,11 @@
+//go:build !amd64
+
+package matcher
+
+func matchLenArch(a, b []byte) int {
+	n := 0
+	for n < len(a) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
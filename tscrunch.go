@@ -1,155 +1,75 @@
 /*
-TSCrunch binary cruncher, by Antonio Savona
-*/
+Package TSCrunch implements the TSCrunch binary cruncher, by Antonio Savona.
 
-package main
+It exposes a small API modeled on compress/flate: Options configures a
+crunch run, New reads a whole source and produces a crunched result, and
+NewWriter/NewReader expose the same behaviour through the io.Writer/io.Reader
+idioms so callers that already work in terms of streams (build tooling,
+test harnesses, in-memory pipelines) don't need to shell out to a CLI.
+*/
+package TSCrunch
 
 import (
 	"bytes"
-	"container/heap"
-	"flag"
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+
+	"github.com/staD020/TSCrunch/internal/matcher"
 	"time"
 )
 
-// ----------------------
-// Local Dijkstra Implementation
-// ----------------------
-
-// Arc represents an edge from one vertex to another with a weight.
-type Arc struct {
-	dest   int
-	weight int64
-}
-
-// Graph holds an adjacency list representation.
-type Graph struct {
-	arcs map[int][]Arc
-	n    int // total number of vertices
-}
-
-// NewGraph creates a new graph with n vertices.
-func NewGraph(n int) *Graph {
-	return &Graph{
-		arcs: make(map[int][]Arc, n),
-		n:    n,
-	}
-}
-
-// AddVertex ensures that vertex v exists.
-func (g *Graph) AddVertex(v int) {
-	if _, ok := g.arcs[v]; !ok {
-		g.arcs[v] = []Arc{}
-	}
-}
-
-// AddArc adds a directed edge from u to v with the given weight.
-func (g *Graph) AddArc(u, v int, weight int64) {
-	g.arcs[u] = append(g.arcs[u], Arc{dest: v, weight: weight})
+// Version is the TSCrunch release this package implements.
+const Version = "1.3"
+
+// Options configures a crunch run. It carries everything crunchCtx used to
+// take straight from CLI flags, so library callers can drive TSCrunch
+// without going through main().
+type Options struct {
+	PRG      bool     // input is a prg, first 2 bytes are the load address
+	QUIET    bool     // suppress progress/stat output
+	STATS    bool     // print phase timings (ignored when QUIET)
+	INPLACE  bool     // inplace crunching (forces PRG)
+	SFX      bool     // produce a self-extracting prg (forces PRG)
+	SFXMODE  int      // 0: sfx code at top of memory, 1: sfx code on stack
+	BLANK    bool     // blank the screen during decrunching (SFX only)
+	JumpTo   string   // $addr | 0xaddr | addr, required when SFX is set
+	Platform Platform // target machine for the SFX boot stub; zero value is C64
+
+	SkipRLE bool // skip RLE/zero-run candidates; trades ratio for speed
+	Fast    bool // bound the match finder; trades ratio for speed
+
+	// Progress, if set, is called periodically while the LZ layer is being
+	// populated, the slowest phase of crunch. It may be called from a
+	// goroutine other than the caller of New and must return quickly.
+	Progress func(FileStats)
+	// ProgressInterval is the sampling interval for Progress; zero defaults
+	// to one second. Ignored when Progress is nil.
+	ProgressInterval time.Duration
 }
 
-// Item is an element in the priority queue.
-type Item struct {
-	vertex   int
-	priority int64
-	index    int // index in the heap
+// FileStats is a Progress sample. Pos and Tokens are cumulative since the
+// start of the crunch; the Delta fields are the change since the previous
+// sample (or since the start, for the first one).
+type FileStats struct {
+	File        string // set by CrunchFiles; empty when Progress is driven by New directly
+	Pos         int    // source bytes whose candidate tokens have been computed
+	Tokens      int    // candidate tokens emitted so far
+	PosDelta    int
+	TokensDelta int
 }
 
-// PriorityQueue implements heap.Interface.
-type PriorityQueue []*Item
-
-func (pq PriorityQueue) Len() int { return len(pq) }
-func (pq PriorityQueue) Less(i, j int) bool {
-	return pq[i].priority < pq[j].priority
-}
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
-}
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := len(*pq)
-	item := x.(*Item)
-	item.index = n
-	*pq = append(*pq, item)
-}
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil // avoid memory leak
-	item.index = -1
-	*pq = old[0 : n-1]
-	return item
-}
-
-// Shortest computes the shortest path from source to target using Dijkstra’s algorithm.
-// It returns the path (as a slice of vertex indices), the total cost, and a flag indicating success.
-func (g *Graph) Shortest(source, target int) (path []int, cost int64, found bool) {
-	const INF = math.MaxInt64
-	dist := make([]int64, g.n)
-	prev := make([]int, g.n)
-	for i := 0; i < g.n; i++ {
-		dist[i] = INF
-		prev[i] = -1
-	}
-	dist[source] = 0
-
-	pq := make(PriorityQueue, 0, g.n)
-	heap.Init(&pq)
-	heap.Push(&pq, &Item{vertex: source, priority: 0})
-
-	for pq.Len() > 0 {
-		item := heap.Pop(&pq).(*Item)
-		u := item.vertex
-		if u == target {
-			break
-		}
-		for _, arc := range g.arcs[u] {
-			alt := dist[u] + arc.weight
-			if alt < dist[arc.dest] {
-				dist[arc.dest] = alt
-				prev[arc.dest] = u
-				heap.Push(&pq, &Item{vertex: arc.dest, priority: alt})
-			}
-		}
-	}
-
-	if dist[target] == INF {
-		return nil, 0, false
-	}
-
-	// Reconstruct the path.
-	for u := target; u != -1; u = prev[u] {
-		path = append(path, u)
-	}
-	// Reverse the path to get source->target.
-	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
-		path[i], path[j] = path[j], path[i]
-	}
-
-	return path, dist[target], true
-}
-
-// ----------------------
-// End Local Dijkstra Implementation
-// ----------------------
-
-// Go TSCrunch Code
-
+// crunchCtx carries the derived, already-validated state an Options value
+// expands to, plus the bookkeeping crunch accumulates as it runs.
 type crunchCtx struct {
-	QUIET          bool
-	STATS          bool
-	PRG            bool
-	SFX            bool
-	SFXMODE        int
-	BLANK          bool
-	INPLACE        bool
+	opt            Options
 	jmp            uint16
 	decrunchTo     uint16
 	loadTo         uint16
@@ -163,9 +83,47 @@ type crunchCtx struct {
 	usePrefixArray bool
 }
 
-type edge struct {
-	n0 int
-	n1 int
+// newCrunchCtx validates opt and expands it into a crunchCtx.
+func newCrunchCtx(opt Options) (*crunchCtx, error) {
+	ctx := &crunchCtx{
+		opt:            opt,
+		usePrefixArray: true,
+	}
+	if opt.JumpTo != "" {
+		opt.SFX = true
+	}
+	if opt.SFX {
+		opt.PRG = true
+		var jmp uint64
+		var err error
+		switch {
+		case len(opt.JumpTo) > 0 && opt.JumpTo[0] == '$':
+			jmp, err = strconv.ParseUint(opt.JumpTo[1:], 16, 16)
+		case len(opt.JumpTo) > 1 && (opt.JumpTo[:2] == "0x" || opt.JumpTo[:2] == "0X"):
+			jmp, err = strconv.ParseUint(opt.JumpTo[2:], 16, 16)
+		default:
+			jmp, err = strconv.ParseUint(opt.JumpTo, 10, 16)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tscrunch: invalid jump address %q: %w", opt.JumpTo, err)
+		}
+		ctx.jmp = uint16(jmp)
+		if ctx.jmp == 0 {
+			return nil, fmt.Errorf("tscrunch: invalid jump address %q", opt.JumpTo)
+		}
+		info, ok := platforms[opt.Platform]
+		if !ok {
+			return nil, fmt.Errorf("tscrunch: unknown platform %v", opt.Platform)
+		}
+		if opt.BLANK && info.blankReg == 0 {
+			return nil, fmt.Errorf("tscrunch: platform %s has no screen-blank register", opt.Platform)
+		}
+	}
+	if opt.INPLACE {
+		opt.PRG = true
+	}
+	ctx.opt = opt
+	return ctx, nil
 }
 
 type token struct {
@@ -176,11 +134,6 @@ type token struct {
 	i         int
 }
 
-type tokenEntry struct {
-	e edge
-	t token
-}
-
 const LONGESTRLE = 64
 const LONGESTLONGLZ = 64
 const LONGESTLZ = 32
@@ -219,19 +172,6 @@ func max(x, y int) int {
 	return y
 }
 
-func load_raw(f string) []byte {
-	data, err := os.ReadFile(f)
-	if err == nil {
-		return data
-	}
-	fmt.Println("can't read data")
-	return nil
-}
-
-func save_raw(f string, data []byte) {
-	os.WriteFile(f, data, 0666)
-}
-
 func fillPrefixArray(data []byte, ctx *crunchCtx) {
 	ctx.prefixArray = make(map[[MINLZ]byte][]int)
 	for i := 0; i < len(data)-MINLZ; i++ {
@@ -247,6 +187,10 @@ func findall(data []byte, prefix []byte, i int, minlz int, ctx *crunchCtx) <-cha
 	if ctx.usePrefixArray {
 		parray := ctx.prefixArray[*(*[MINLZ]byte)(prefix[:MINLZ])]
 		go func() {
+			if len(parray) == 0 {
+				close(c)
+				return
+			}
 			l := 0
 			h := len(parray) - 1
 			var mid int
@@ -261,10 +205,14 @@ func findall(data []byte, prefix []byte, i int, minlz int, ctx *crunchCtx) <-cha
 					l = mid
 				}
 			}
-			for o := mid; o >= 0 && parray[o] > x0; o-- {
-				if parray[o] < i && bytes.Equal(data[parray[o]:parray[o]+minlz], prefix) {
-					c <- parray[o]
-				}
+			limit := mid + 1
+			if ctx.opt.Fast && limit > fastMaxCandidates {
+				limit = fastMaxCandidates
+			}
+			found := make([]int32, limit)
+			n := matcher.FindCandidates(data, prefix[:minlz], parray[:mid+1], i, x0, found)
+			for _, p := range found[:n] {
+				c <- int(p)
 			}
 			close(c)
 		}()
@@ -284,6 +232,10 @@ func findall(data []byte, prefix []byte, i int, minlz int, ctx *crunchCtx) <-cha
 	return c
 }
 
+// fastMaxCandidates bounds how many same-prefix positions findall walks
+// when Options.Fast is set, trading some ratio for match-finder speed.
+const fastMaxCandidates = 64
+
 func findOptimalZeroRun(src []byte) int {
 	zeroruns := make(map[int]int)
 	var i, j int
@@ -368,10 +320,8 @@ func LZ(src []byte, i int, size int, offset int, minlz int, ctx *crunchCtx) toke
 		if len(src)-i >= minlz {
 			prefixes := findall(src, src[i:i+minlz], i, minlz, ctx)
 			for j := range prefixes {
-				l := minlz
-				for i+l < len(src) && l < LONGESTLONGLZ && src[j+l] == src[i+l] {
-					l++
-				}
+				extra := matcher.MatchLen(src[j+minlz:], src[i+minlz:], min(LONGESTLONGLZ-minlz, len(src)-i-minlz))
+				l := minlz + extra
 				if (l > bestlen && (i-j < LZOFFSET || i-bestpos >= LZOFFSET || l > LONGESTLZ)) || (l > bestlen+1) {
 					bestpos = j
 					bestlen = l
@@ -456,11 +406,19 @@ func LIT(i int, size int) token {
 	return lit
 }
 
-// crunchAtByteWorker processes a single source position and returns any tokens found.
-func crunchAtByteWorker(src []byte, i int, ctx *crunchCtx) []tokenEntry {
-	entries := []tokenEntry{}
+// crunchAtByteWorker computes the candidate tokens starting at position i:
+// the best LZ match per shrinking size down to the RLE cutoff, every RLE
+// length down to MINRLE, the LZ2 and zero-run matches if any, and every
+// literal length up to LONGESTLITERAL. posCandidates[i] in crunch holds
+// exactly this set, and the forward DP picks the cheapest one per position.
+func crunchAtByteWorker(src []byte, i int, ctx *crunchCtx) []token {
+	entries := []token{}
 	rle := RLE(src, i, 0, 0)
 	rlesize := min(rle.size, LONGESTRLE)
+	if ctx.opt.SkipRLE {
+		rle = token{}
+		rlesize = 0
+	}
 	var lz, lz2 token
 	if rlesize < LONGESTLONGLZ-1 {
 		lz = LZ(src, i, 0, 0, max(rlesize+1, MINLZ), ctx)
@@ -470,117 +428,530 @@ func crunchAtByteWorker(src []byte, i int, ctx *crunchCtx) []tokenEntry {
 	if len(src)-i > 2 {
 		lz2 = LZ2(src, i, 0, 0)
 	}
-	zero := ZERORUN(src, i, ctx.optimalRun)
+	var zero token
+	if !ctx.opt.SkipRLE {
+		zero = ZERORUN(src, i, ctx.optimalRun)
+	}
 	for size := lz.size; size >= MINLZ && size > rlesize; size-- {
-		tokenCopy := LZ(src, -1, size, lz.offset, MINLZ, ctx)
-		entries = append(entries, tokenEntry{e: edge{i, i + size}, t: tokenCopy})
+		t := LZ(src, -1, size, lz.offset, MINLZ, ctx)
+		t.i = i
+		entries = append(entries, t)
 	}
-	if rle.size > LONGESTRLE {
-		entries = append(entries, tokenEntry{e: edge{i, i + LONGESTRLE}, t: RLE(src, -1, LONGESTRLE, src[i])})
-	} else {
-		for size := rle.size; size >= MINRLE; size-- {
-			entries = append(entries, tokenEntry{e: edge{i, i + size}, t: RLE(src, -1, size, src[i])})
+	if !ctx.opt.SkipRLE {
+		if rle.size > LONGESTRLE {
+			t := RLE(src, -1, LONGESTRLE, src[i])
+			t.i = i
+			entries = append(entries, t)
+		} else {
+			for size := rle.size; size >= MINRLE; size-- {
+				t := RLE(src, -1, size, src[i])
+				t.i = i
+				entries = append(entries, t)
+			}
 		}
 	}
 	if lz2.size == 2 {
-		entries = append(entries, tokenEntry{e: edge{i, i + 2}, t: lz2})
+		entries = append(entries, lz2)
 	}
 	if zero.size != 0 {
-		entries = append(entries, tokenEntry{e: edge{i, i + ctx.optimalRun}, t: zero})
+		entries = append(entries, zero)
+	}
+	for size := 1; size < min(LONGESTLITERAL+1, len(src)+1-i); size++ {
+		entries = append(entries, LIT(i, size))
 	}
 	return entries
 }
 
-func crunch(src []byte, ctx *crunchCtx) []byte {
-	// Boot blocks.
-	var boot = []byte{
-		0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
-		0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
-		0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
-		0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
-		0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
-		0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
-		0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
-		0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
-		0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
-		0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xA9,
-		0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
-		0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
-		0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
-		0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
-		0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
-		0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
-		0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
-		0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
-		0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
-		0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
-	}
-
-	var blank_boot = []byte{
-		0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
-		0x00, 0x00, 0x78, 0xA9, 0x0B, 0x8D, 0x11, 0xD0, 0xA2, 0xCF, 0xBD, 0x1F,
-		0x08, 0x95, 0x00, 0xCA, 0xD0, 0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00,
-		0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7,
-		0x04, 0x90, 0xEF, 0xA0, 0x00, 0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0,
-		0x47, 0xE6, 0x24, 0xD0, 0x02, 0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF,
-		0xFF, 0xC8, 0xCA, 0xD0, 0xF6, 0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85,
-		0x27, 0xB0, 0x77, 0x8A, 0x65, 0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25,
-		0xB0, 0xD3, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59,
-		0xC8, 0xB1, 0x24, 0xA4, 0x59, 0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB,
-		0xA9, 0x00, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00,
-		0xF0, 0xF6, 0x09, 0x80, 0x65, 0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00,
-		0x85, 0xA2, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98,
-		0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33,
-		0xF1, 0x24, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02,
-		0xA0, 0x00, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8,
-		0xB9, 0xA1, 0x00, 0x91, 0x27, 0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00,
-		0xB0, 0x83, 0xE6, 0x28, 0x18, 0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2,
-		0x01, 0xD0, 0x96, 0x71, 0x24, 0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80,
-		0x65, 0x28, 0x85, 0xA2, 0xE0, 0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
-	}
-
-	var boot2 = []byte{
-		0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
-		0x00, 0x00, 0x78, 0xA9, 0x34, 0x85, 0x01, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
-		0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
-		0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
-		0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
-		0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
-		0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
-		0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
-		0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
-		0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
-		0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58,
-		0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
-		0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
-		0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
-		0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
-		0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
-		0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
-		0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
-		0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
-		0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
-		0xA2, 0x03, 0xD0, 0xC4,
-	}
-
-	// Create a graph with len(src)+1 vertices.
-	g := NewGraph(len(src) + 1)
-	for i := 0; i < len(src)+1; i++ {
-		g.AddVertex(i)
+// Platform identifies the target machine an SFX boot stub is built for.
+// The zero value is C64, so Options callers that never set Platform keep
+// today's behaviour.
+type Platform int
+
+const (
+	C64 Platform = iota
+	C128
+	VIC20_8K
+	VIC20_24K
+	PLUS4
+	PET
+)
+
+func (p Platform) String() string {
+	switch p {
+	case C64:
+		return "c64"
+	case C128:
+		return "c128"
+	case VIC20_8K:
+		return "vic20+8k"
+	case VIC20_24K:
+		return "vic20+24k"
+	case PLUS4:
+		return "plus4"
+	case PET:
+		return "pet"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePlatform maps a -t flag value to a Platform. Matching is
+// case-insensitive; an empty string is treated as "c64".
+func ParsePlatform(s string) (Platform, error) {
+	switch strings.ToLower(s) {
+	case "", "c64":
+		return C64, nil
+	case "c128":
+		return C128, nil
+	case "vic20+8k", "vic20-8k":
+		return VIC20_8K, nil
+	case "vic20+24k", "vic20-24k":
+		return VIC20_24K, nil
+	case "plus4":
+		return PLUS4, nil
+	case "pet":
+		return PET, nil
+	default:
+		return 0, fmt.Errorf("tscrunch: unknown platform %q", s)
+	}
+}
+
+// platformInfo describes the parts of a platform's memory map and I/O
+// layout the SFX boot stub needs to account for: where BASIC autostarts,
+// how high decrunching may legally run before hitting ROM/IO, and which
+// register (if any) blanks the screen while decrunching runs. None of
+// these boot stubs bank any ROM out of the way (the C64/C128 $01 port
+// writes are the one exception, baked into c64Boot/c128Boot themselves);
+// memTop is therefore capped at the highest address that is RAM without
+// any bank switching, not the platform's total RAM size.
+type platformInfo struct {
+	basicStart uint16
+	memTop     uint16
+	blankReg   uint16 // 0 if the platform has no usable screen-blank register
+}
+
+var platforms = map[Platform]platformInfo{
+	C64:       {basicStart: 0x0801, memTop: 0xffff, blankReg: 0xd011},
+	C128:      {basicStart: 0x1c01, memTop: 0xffff, blankReg: 0xd011},
+	VIC20_8K:  {basicStart: 0x1201, memTop: 0x3fff, blankReg: 0x9000},
+	VIC20_24K: {basicStart: 0x1201, memTop: 0x7fff, blankReg: 0x9000},
+	// Plus/4 BASIC+KERNAL ROM occupies $8000-$FFFF unless banked out via
+	// the TED $FF3E/$FF3F registers, which this boot stub does not do (see
+	// sfx/plus4.s); memTop is capped to the 32K below ROM until that bank
+	// switching is implemented.
+	PLUS4: {basicStart: 0x1001, memTop: 0x7fff, blankReg: 0xff06},
+	PET:   {basicStart: 0x0401, memTop: 0x7fff, blankReg: 0},
+}
+
+// bootSet returns the boot, blank-screen boot and stack-mode boot
+// templates for p. See sfx/*.s for the commented 6502 source each of
+// these was assembled from; crunch takes a defensive copy before
+// patching in per-file addresses, since these are shared templates.
+// blankBoot is nil for platforms platformInfo reports as having no
+// screen-blank register.
+func bootSet(p Platform) (boot, blankBoot, boot2 []byte) {
+	switch p {
+	case C128:
+		return c128Boot, c128BlankBoot, c128Boot2
+	case VIC20_8K, VIC20_24K:
+		return vic20Boot, vic20BlankBoot, vic20Boot2
+	case PLUS4:
+		return plus4Boot, plus4BlankBoot, plus4Boot2
+	case PET:
+		return petBoot, nil, petBoot2
+	default:
+		return c64Boot, c64BlankBoot, c64Boot2
+	}
+}
+
+// c64Boot, c64BlankBoot and c64Boot2 are the 6502 self-extractor boot
+// stubs for the C64. crunch takes a defensive copy before patching in
+// per-file addresses, since these are shared templates.
+var c64Boot = []byte{
+	0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
+	0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
+	0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
+	0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
+	0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
+	0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
+	0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
+	0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
+	0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xA9,
+	0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
+	0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
+	0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
+	0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
+	0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
+	0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
+	0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
+	0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var c64BlankBoot = []byte{
+	0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x0B, 0x8D, 0x11, 0xD0, 0xA2, 0xCF, 0xBD, 0x1F,
+	0x08, 0x95, 0x00, 0xCA, 0xD0, 0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00,
+	0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7,
+	0x04, 0x90, 0xEF, 0xA0, 0x00, 0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0,
+	0x47, 0xE6, 0x24, 0xD0, 0x02, 0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF,
+	0xFF, 0xC8, 0xCA, 0xD0, 0xF6, 0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85,
+	0x27, 0xB0, 0x77, 0x8A, 0x65, 0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25,
+	0xB0, 0xD3, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59,
+	0xC8, 0xB1, 0x24, 0xA4, 0x59, 0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB,
+	0xA9, 0x00, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00,
+	0xF0, 0xF6, 0x09, 0x80, 0x65, 0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00,
+	0x85, 0xA2, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98,
+	0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33,
+	0xF1, 0x24, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02,
+	0xA0, 0x00, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8,
+	0xB9, 0xA1, 0x00, 0x91, 0x27, 0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00,
+	0xB0, 0x83, 0xE6, 0x28, 0x18, 0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2,
+	0x01, 0xD0, 0x96, 0x71, 0x24, 0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80,
+	0x65, 0x28, 0x85, 0xA2, 0xE0, 0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var c64Boot2 = []byte{
+	0x01, 0x08, 0x0B, 0x08, 0x0A, 0x00, 0x9E, 0x32, 0x30, 0x36, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x34, 0x85, 0x01, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
+	0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
+	0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
+	0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
+	0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
+	0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
+	0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
+	0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
+	0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
+	0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58,
+	0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
+	0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
+	0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
+	0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
+	0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
+	0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
+	0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
+	0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
+	0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
+	0xA2, 0x03, 0xD0, 0xC4,
+}
+
+// c128Boot, c128BlankBoot and c128Boot2 through petBoot/petBoot2 are the
+// equivalent boot stubs for the other platforms Options.Platform accepts.
+// Each is assembled from the matching sfx/*.s source: only the BASIC
+// autostart header (load address, and the SYS target recomputed for it)
+// and, on platforms without a $01-style banking port, the instructions
+// that poke it, differ from c64Boot/c64BlankBoot/c64Boot2 above - the
+// decrunch code itself is identical, so every patch offset crunch uses
+// below (boot[0x1e], boot[0x3f], boot2[0x26], ...) stays valid across
+// platforms. PET has no VIC-equivalent chip, so it has no blank variant.
+var c128Boot = []byte{
+	0x01, 0x1C, 0x0B, 0x1C, 0x0A, 0x00, 0x9E, 0x37, 0x31, 0x38, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
+	0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
+	0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
+	0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
+	0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
+	0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
+	0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
+	0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
+	0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xA9,
+	0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
+	0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
+	0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
+	0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
+	0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
+	0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
+	0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
+	0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var c128BlankBoot = []byte{
+	0x01, 0x1C, 0x0B, 0x1C, 0x0A, 0x00, 0x9E, 0x37, 0x31, 0x38, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x0B, 0x8D, 0x11, 0xD0, 0xA2, 0xCF, 0xBD, 0x1F,
+	0x08, 0x95, 0x00, 0xCA, 0xD0, 0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00,
+	0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7,
+	0x04, 0x90, 0xEF, 0xA0, 0x00, 0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0,
+	0x47, 0xE6, 0x24, 0xD0, 0x02, 0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF,
+	0xFF, 0xC8, 0xCA, 0xD0, 0xF6, 0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85,
+	0x27, 0xB0, 0x77, 0x8A, 0x65, 0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25,
+	0xB0, 0xD3, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59,
+	0xC8, 0xB1, 0x24, 0xA4, 0x59, 0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB,
+	0xA9, 0x00, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58, 0x4C, 0x61, 0x00,
+	0xF0, 0xF6, 0x09, 0x80, 0x65, 0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00,
+	0x85, 0xA2, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98,
+	0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33,
+	0xF1, 0x24, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02,
+	0xA0, 0x00, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8,
+	0xB9, 0xA1, 0x00, 0x91, 0x27, 0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00,
+	0xB0, 0x83, 0xE6, 0x28, 0x18, 0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2,
+	0x01, 0xD0, 0x96, 0x71, 0x24, 0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80,
+	0x65, 0x28, 0x85, 0xA2, 0xE0, 0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var c128Boot2 = []byte{
+	0x01, 0x1C, 0x0B, 0x1C, 0x0A, 0x00, 0x9E, 0x37, 0x31, 0x38, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x34, 0x85, 0x01, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
+	0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
+	0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
+	0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
+	0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
+	0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
+	0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
+	0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
+	0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
+	0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xA9, 0x37, 0x85, 0x01, 0x58,
+	0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
+	0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
+	0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
+	0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
+	0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
+	0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
+	0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
+	0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
+	0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
+	0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var vic20Boot = []byte{
+	0x01, 0x12, 0x0B, 0x12, 0x0A, 0x00, 0x9E, 0x34, 0x36, 0x32, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
+	0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
+	0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
+	0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
+	0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
+	0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
+	0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
+	0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
+	0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xEA,
+	0xEA, 0xEA, 0xEA, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
+	0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
+	0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
+	0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
+	0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
+	0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
+	0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
+	0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var vic20BlankBoot = []byte{
+	0x01, 0x12, 0x0B, 0x12, 0x0A, 0x00, 0x9E, 0x34, 0x36, 0x32, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x0B, 0x8D, 0x00, 0x90, 0xA2, 0xCF, 0xBD, 0x1F,
+	0x08, 0x95, 0x00, 0xCA, 0xD0, 0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00,
+	0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7,
+	0x04, 0x90, 0xEF, 0xA0, 0x00, 0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0,
+	0x47, 0xE6, 0x24, 0xD0, 0x02, 0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF,
+	0xFF, 0xC8, 0xCA, 0xD0, 0xF6, 0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85,
+	0x27, 0xB0, 0x77, 0x8A, 0x65, 0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25,
+	0xB0, 0xD3, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59,
+	0xC8, 0xB1, 0x24, 0xA4, 0x59, 0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB,
+	0xA9, 0x00, 0xB0, 0xD5, 0xEA, 0xEA, 0xEA, 0xEA, 0x58, 0x4C, 0x61, 0x00,
+	0xF0, 0xF6, 0x09, 0x80, 0x65, 0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00,
+	0x85, 0xA2, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98,
+	0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33,
+	0xF1, 0x24, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02,
+	0xA0, 0x00, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8,
+	0xB9, 0xA1, 0x00, 0x91, 0x27, 0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00,
+	0xB0, 0x83, 0xE6, 0x28, 0x18, 0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2,
+	0x01, 0xD0, 0x96, 0x71, 0x24, 0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80,
+	0x65, 0x28, 0x85, 0xA2, 0xE0, 0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var vic20Boot2 = []byte{
+	0x01, 0x12, 0x0B, 0x12, 0x0A, 0x00, 0x9E, 0x34, 0x36, 0x32, 0x31, 0x00,
+	0x00, 0x00, 0x78, 0xEA, 0xEA, 0xEA, 0xEA, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
+	0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
+	0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
+	0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
+	0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
+	0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
+	0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
+	0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
+	0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
+	0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xEA, 0xEA, 0xEA, 0xEA, 0x58,
+	0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
+	0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
+	0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
+	0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
+	0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
+	0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
+	0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
+	0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
+	0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
+	0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var plus4Boot = []byte{
+	0x01, 0x10, 0x0B, 0x10, 0x0A, 0x00, 0x9E, 0x34, 0x31, 0x30, 0x39, 0x00,
+	0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
+	0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
+	0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
+	0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
+	0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
+	0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
+	0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
+	0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
+	0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xEA,
+	0xEA, 0xEA, 0xEA, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
+	0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
+	0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
+	0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
+	0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
+	0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
+	0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
+	0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var plus4BlankBoot = []byte{
+	0x01, 0x10, 0x0B, 0x10, 0x0A, 0x00, 0x9E, 0x34, 0x31, 0x30, 0x39, 0x00,
+	0x00, 0x00, 0x78, 0xA9, 0x0B, 0x8D, 0x06, 0xFF, 0xA2, 0xCF, 0xBD, 0x1F,
+	0x08, 0x95, 0x00, 0xCA, 0xD0, 0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00,
+	0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7,
+	0x04, 0x90, 0xEF, 0xA0, 0x00, 0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0,
+	0x47, 0xE6, 0x24, 0xD0, 0x02, 0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF,
+	0xFF, 0xC8, 0xCA, 0xD0, 0xF6, 0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85,
+	0x27, 0xB0, 0x77, 0x8A, 0x65, 0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25,
+	0xB0, 0xD3, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59,
+	0xC8, 0xB1, 0x24, 0xA4, 0x59, 0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB,
+	0xA9, 0x00, 0xB0, 0xD5, 0xEA, 0xEA, 0xEA, 0xEA, 0x58, 0x4C, 0x61, 0x00,
+	0xF0, 0xF6, 0x09, 0x80, 0x65, 0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00,
+	0x85, 0xA2, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98,
+	0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33,
+	0xF1, 0x24, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02,
+	0xA0, 0x00, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8,
+	0xB9, 0xA1, 0x00, 0x91, 0x27, 0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00,
+	0xB0, 0x83, 0xE6, 0x28, 0x18, 0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2,
+	0x01, 0xD0, 0x96, 0x71, 0x24, 0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80,
+	0x65, 0x28, 0x85, 0xA2, 0xE0, 0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var plus4Boot2 = []byte{
+	0x01, 0x10, 0x0B, 0x10, 0x0A, 0x00, 0x9E, 0x34, 0x31, 0x30, 0x39, 0x00,
+	0x00, 0x00, 0x78, 0xEA, 0xEA, 0xEA, 0xEA, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
+	0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
+	0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
+	0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
+	0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
+	0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
+	0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
+	0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
+	0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
+	0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xEA, 0xEA, 0xEA, 0xEA, 0x58,
+	0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
+	0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
+	0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
+	0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
+	0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
+	0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
+	0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
+	0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
+	0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
+	0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var petBoot = []byte{
+	0x01, 0x04, 0x0B, 0x04, 0x0A, 0x00, 0x9E, 0x31, 0x30, 0x33, 0x37, 0x00,
+	0x00, 0x00, 0x78, 0xA2, 0xCF, 0xBD, 0x1A, 0x08, 0x95, 0x00, 0xCA, 0xD0,
+	0xF8, 0x4C, 0x02, 0x00, 0x34, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8,
+	0xD0, 0xF7, 0xC6, 0x07, 0xA9, 0x06, 0xC7, 0x04, 0x90, 0xEF, 0xA0, 0x00,
+	0xB3, 0x24, 0x30, 0x29, 0xC9, 0x20, 0xB0, 0x47, 0xE6, 0x24, 0xD0, 0x02,
+	0xE6, 0x25, 0xB9, 0xFF, 0xFF, 0x99, 0xFF, 0xFF, 0xC8, 0xCA, 0xD0, 0xF6,
+	0x98, 0xAA, 0xA0, 0x00, 0x65, 0x27, 0x85, 0x27, 0xB0, 0x77, 0x8A, 0x65,
+	0x24, 0x85, 0x24, 0x90, 0xD7, 0xE6, 0x25, 0xB0, 0xD3, 0x4B, 0x7F, 0x90,
+	0x3A, 0xF0, 0x6B, 0xA2, 0x02, 0x85, 0x59, 0xC8, 0xB1, 0x24, 0xA4, 0x59,
+	0x91, 0x27, 0x88, 0x91, 0x27, 0xD0, 0xFB, 0xA9, 0x00, 0xB0, 0xD5, 0xEA,
+	0xEA, 0xEA, 0xEA, 0x58, 0x4C, 0x61, 0x00, 0xF0, 0xF6, 0x09, 0x80, 0x65,
+	0x27, 0x85, 0xA1, 0xA5, 0x28, 0xE9, 0x00, 0x85, 0xA2, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A,
+	0x85, 0xA6, 0xC8, 0xA5, 0x27, 0x90, 0x33, 0xF1, 0x24, 0x85, 0xA1, 0xA5,
+	0x28, 0xE9, 0x00, 0x85, 0xA2, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xA1, 0x91,
+	0x27, 0xC8, 0xB1, 0xA1, 0x91, 0x27, 0xC8, 0xB9, 0xA1, 0x00, 0x91, 0x27,
+	0xC0, 0x00, 0xD0, 0xF6, 0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0x28, 0x18,
+	0x90, 0x84, 0xA0, 0xFF, 0x84, 0x59, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0x24,
+	0x85, 0xA1, 0xC8, 0xB3, 0x24, 0x09, 0x80, 0x65, 0x28, 0x85, 0xA2, 0xE0,
+	0x80, 0x26, 0xA6, 0xA2, 0x03, 0xD0, 0xC4,
+}
+
+var petBoot2 = []byte{
+	0x01, 0x04, 0x0B, 0x04, 0x0A, 0x00, 0x9E, 0x31, 0x30, 0x33, 0x37, 0x00,
+	0x00, 0x00, 0x78, 0xEA, 0xEA, 0xEA, 0xEA, 0xA2, 0xD3, 0xBD, 0x1F, 0x08,
+	0x9D, 0xFB, 0x00, 0xCA, 0xD0, 0xF7, 0x4C, 0x00, 0x01, 0xAA, 0xAA, 0xAA,
+	0xAA, 0xBD, 0x00, 0x10, 0x9D, 0x00, 0xFF, 0xE8, 0xD0, 0xF7, 0xCE, 0x05,
+	0x01, 0xA9, 0x06, 0xCF, 0x02, 0x01, 0x90, 0xED, 0xA0, 0x00, 0xB3, 0xFC,
+	0x30, 0x27, 0xC9, 0x20, 0xB0, 0x45, 0xE6, 0xFC, 0xD0, 0x02, 0xE6, 0xFD,
+	0xB1, 0xFC, 0x91, 0xFE, 0xC8, 0xCA, 0xD0, 0xF8, 0x98, 0xAA, 0xA0, 0x00,
+	0x65, 0xFE, 0x85, 0xFE, 0xB0, 0x77, 0x8A, 0x65, 0xFC, 0x85, 0xFC, 0x90,
+	0xD9, 0xE6, 0xFD, 0xB0, 0xD5, 0x4B, 0x7F, 0x90, 0x3A, 0xF0, 0x6B, 0xA2,
+	0x02, 0x85, 0xF9, 0xC8, 0xB1, 0xFC, 0xA4, 0xF9, 0x91, 0xFE, 0x88, 0x91,
+	0xFE, 0xD0, 0xFB, 0xA5, 0xF9, 0xB0, 0xD5, 0xEA, 0xEA, 0xEA, 0xEA, 0x58,
+	0x4C, 0x5F, 0x01, 0xF0, 0xF6, 0x09, 0x80, 0x65, 0xFE, 0x85, 0xFA, 0xA5,
+	0xFF, 0xE9, 0x00, 0x85, 0xFB, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA,
+	0x91, 0xFE, 0x98, 0xAA, 0x88, 0xF0, 0xB1, 0x4A, 0x8D, 0xA4, 0x01, 0xC8,
+	0xA5, 0xFE, 0x90, 0x32, 0xF1, 0xFC, 0x85, 0xFA, 0xA5, 0xFF, 0xE9, 0x00,
+	0x85, 0xFB, 0xA2, 0x02, 0xA0, 0x00, 0xB1, 0xFA, 0x91, 0xFE, 0xC8, 0xB1,
+	0xFA, 0x91, 0xFE, 0xC8, 0xB1, 0xFA, 0x91, 0xFE, 0xC0, 0x00, 0xD0, 0xF7,
+	0x98, 0xA0, 0x00, 0xB0, 0x83, 0xE6, 0xFF, 0x18, 0x90, 0x84, 0xA0, 0xFF,
+	0x84, 0xF9, 0xA2, 0x01, 0xD0, 0x96, 0x71, 0xFC, 0x85, 0xFA, 0xC8, 0xB3,
+	0xFC, 0x09, 0x80, 0x65, 0xFF, 0x85, 0xFB, 0xE0, 0x80, 0x2E, 0xA4, 0x01,
+	0xA2, 0x03, 0xD0, 0xC4,
+}
+
+// reportProgress samples pos and tokensEmitted on interval (default one
+// second) and calls report with the cumulative counts and their delta since
+// the previous sample, until done is closed.
+func reportProgress(report func(FileStats), interval time.Duration, pos, tokensEmitted *int64, done <-chan struct{}) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastPos, lastTokens int64
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		p, t := atomic.LoadInt64(pos), atomic.LoadInt64(tokensEmitted)
+		report(FileStats{
+			Pos:         int(p),
+			Tokens:      int(t),
+			PosDelta:    int(p - lastPos),
+			TokensDelta: int(t - lastTokens),
+		})
+		lastPos, lastTokens = p, t
+	}
+}
+
+func crunch(src []byte, ctx *crunchCtx) ([]byte, error) {
+	// Boot blocks; copied so in-place patching below doesn't mutate the shared templates.
+	bootTmpl, blankBootTmpl, boot2Tmpl := bootSet(ctx.opt.Platform)
+	boot := append([]byte(nil), bootTmpl...)
+	boot2 := append([]byte(nil), boot2Tmpl...)
+	var blank_boot []byte
+	if blankBootTmpl != nil {
+		blank_boot = append([]byte(nil), blankBootTmpl...)
 	}
 
 	ctx.sourceLen = len(src)
 	ctx.sourceAbsLen = ctx.sourceLen
 
 	remainder := []byte{}
-	if ctx.PRG {
+	if ctx.opt.PRG {
 		ctx.addr = src[:2]
 		src = src[2:]
 		ctx.decrunchTo = uint16(ctx.addr[0]) + 256*uint16(ctx.addr[1])
 		ctx.sourceAbsLen -= 2
 	}
 
-	if ctx.INPLACE {
+	if ctx.opt.INPLACE {
 		remainder = src[len(src)-1:]
 		src = src[:len(src)-1]
 	}
@@ -590,99 +961,86 @@ func crunch(src []byte, ctx *crunchCtx) []byte {
 		fillPrefixArray(src, ctx)
 	}
 
-	if !ctx.QUIET {
+	if !ctx.opt.QUIET {
 		fmt.Print("Populating LZ layer")
 	}
 	tm := time.Now()
 
-	// --- Worker pool with collector goroutine ---
+	// --- Worker pool ---
+	// Each worker writes its position's candidates directly into its own
+	// slot of posCandidates, so positions never contend with each other
+	// and there is no map/channel in the hot path.
+	posCandidates := make([][]token, len(src))
 	numWorkers := runtime.GOMAXPROCS(0)
 	jobs := make(chan int, numWorkers*2)
-	results := make(chan tokenEntry, numWorkers*4)
-
-	// Collector: merge results concurrently into tokenMap.
-	tokenMap := make(map[edge]token)
-	var collectorWg sync.WaitGroup
-	collectorWg.Add(1)
-	go func() {
-		defer collectorWg.Done()
-		for entry := range results {
-			tokenMap[entry.e] = entry.t
-		}
-	}()
-
-	// Launch workers.
 	var wg sync.WaitGroup
+	var pos, tokensEmitted int64
 	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
-				entries := crunchAtByteWorker(src, i, ctx)
-				for _, entry := range entries {
-					results <- entry
-				}
+				c := crunchAtByteWorker(src, i, ctx)
+				posCandidates[i] = c
+				atomic.AddInt64(&pos, 1)
+				atomic.AddInt64(&tokensEmitted, int64(len(c)))
 			}
 		}()
 	}
-
-	// Send jobs.
+	var progressDone chan struct{}
+	if ctx.opt.Progress != nil {
+		progressDone = make(chan struct{})
+		go reportProgress(ctx.opt.Progress, ctx.opt.ProgressInterval, &pos, &tokensEmitted, progressDone)
+	}
 	for i := 0; i < len(src); i++ {
 		jobs <- i
 	}
 	close(jobs)
 	wg.Wait()
-	close(results)
-	collectorWg.Wait()
-	// --- End worker pool ---
-
-	if !ctx.QUIET {
-		if ctx.STATS {
-			fmt.Println(" ...", time.Since(tm))
-		} else {
-			fmt.Println()
-		}
-		fmt.Print("Closing Gaps")
-	}
-	// Fill gaps with literal tokens.
-	for i := 0; i < len(src); i++ {
-		for j := 1; j < min(LONGESTLITERAL+1, len(src)+1-i); j++ {
-			key := edge{i, i + j}
-			if _, exists := tokenMap[key]; !exists {
-				tokenMap[key] = LIT(i, j)
-			}
-		}
+	if progressDone != nil {
+		close(progressDone)
 	}
+	// --- End worker pool ---
 
-	if !ctx.QUIET {
-		if ctx.STATS {
+	if !ctx.opt.QUIET {
+		if ctx.opt.STATS {
 			fmt.Println(" ...", time.Since(tm))
 		} else {
 			fmt.Println()
 		}
-		fmt.Print("Populating Graph")
+		fmt.Print("Computing shortest path")
 	}
 	tm = time.Now()
-	for k, t := range tokenMap {
-		g.AddArc(k.n0, k.n1, tokenCost(k.n0, k.n1, t.tokentype))
-	}
 
-	if !ctx.QUIET {
-		if ctx.STATS {
-			fmt.Println(" ...", time.Since(tm))
-		} else {
-			fmt.Println()
+	// Forward DP: dp[n] is the cheapest cost to reach position n, and
+	// pred[n] is the token whose consumption lands exactly on n. Each
+	// position only ever considers its own small, constant-size candidate
+	// set, so this is O(N*W) in both time and memory instead of building
+	// a full edge map up front.
+	const inf = int64(math.MaxInt64)
+	dp := make([]int64, len(src)+1)
+	pred := make([]token, len(src)+1)
+	for n := 1; n <= len(src); n++ {
+		dp[n] = inf
+	}
+	for i := 0; i < len(src); i++ {
+		if dp[i] == inf {
+			continue
+		}
+		for _, t := range posCandidates[i] {
+			n1 := i + t.size
+			cost := dp[i] + tokenCost(i, n1, t.tokentype)
+			if cost < dp[n1] {
+				dp[n1] = cost
+				pred[n1] = t
+			}
 		}
-		fmt.Print("Computing shortest path")
 	}
-	tm = time.Now()
-	bestPath, _, found := g.Shortest(0, len(src))
-	if !found {
-		fmt.Println("No valid path found")
-		os.Exit(1)
+	if dp[len(src)] == inf {
+		return nil, fmt.Errorf("no valid path found")
 	}
-	if !ctx.QUIET {
-		if ctx.STATS {
+	if !ctx.opt.QUIET {
+		if ctx.opt.STATS {
 			fmt.Println(" ...", time.Since(tm))
 		} else {
 			fmt.Println()
@@ -691,12 +1049,16 @@ func crunch(src []byte, ctx *crunchCtx) []byte {
 
 	crunched := make([]byte, 0)
 	token_list := make([]token, 0)
-	for i := 0; i < len(bestPath)-1; i++ {
-		e := edge{bestPath[i], bestPath[i+1]}
-		token_list = append(token_list, tokenMap[e])
+	for n := len(src); n > 0; {
+		t := pred[n]
+		token_list = append(token_list, t)
+		n = t.i
+	}
+	for l, r := 0, len(token_list)-1; l < r; l, r = l+1, r-1 {
+		token_list[l], token_list[r] = token_list[r], token_list[l]
 	}
 
-	if ctx.INPLACE {
+	if ctx.opt.INPLACE {
 		safety := len(token_list)
 		segmentUncrunchedSize := 0
 		segmentCrunchedSize := 0
@@ -727,16 +1089,16 @@ func crunch(src []byte, ctx *crunchCtx) []byte {
 			crunched = append(crunched, tokenPayload(src, t)...)
 		}
 		crunched = append(crunched, TERMINATOR)
-		if !ctx.SFX {
+		if !ctx.opt.SFX {
 			crunched = append([]byte{byte(ctx.optimalRun - 1)}, crunched...)
 		}
 	}
 
 	ctx.crunchedSize = len(crunched)
-	if ctx.SFX {
-		if ctx.SFXMODE == 0 {
+	if ctx.opt.SFX {
+		if ctx.opt.SFXMODE == 0 {
 			gap := 0
-			if ctx.BLANK {
+			if ctx.opt.BLANK {
 				gap = 5
 				boot = blank_boot
 			}
@@ -771,112 +1133,234 @@ func crunch(src []byte, ctx *crunchCtx) []byte {
 		}
 		crunched = append(boot, crunched...)
 		ctx.crunchedSize += len(boot)
-		ctx.loadTo = 0x0801
+		ctx.loadTo = platforms[ctx.opt.Platform].basicStart
 	}
 
 	ctx.decrunchEnd = uint16(int(ctx.decrunchTo) + ctx.sourceAbsLen - 1)
-	if ctx.INPLACE {
+	if ctx.opt.SFX {
+		if memTop := platforms[ctx.opt.Platform].memTop; ctx.decrunchEnd > memTop {
+			return nil, fmt.Errorf("tscrunch: decrunch end $%04x exceeds %s memory top $%04x", ctx.decrunchEnd, ctx.opt.Platform, memTop)
+		}
+	}
+	if ctx.opt.INPLACE {
 		ctx.loadTo = ctx.decrunchEnd - uint16(len(crunched)) + 1
 		crunched = append([]byte{byte(ctx.loadTo & 255), byte(ctx.loadTo >> 8)}, crunched...)
 	}
-	return crunched
+	return crunched, nil
 }
 
-func usage() {
-	fmt.Println("TSCrunch 1.3 - binary cruncher, by Antonio Savona")
-	fmt.Println("Usage: tscrunch [-p] [-i] [-q] [-x[2] $addr] infile outfile")
-	fmt.Println(" -p  : input file is a prg, first 2 bytes are discarded.")
-	fmt.Println(" -x  $addr: creates a self extracting file (forces -p)")
-	fmt.Println(" -x2 $addr: creates a self extracting file with sfx code in stack (forces -p)")
-	fmt.Println(" -b  : blanks screen during decrunching (only with -x)")
-	fmt.Println(" -i  : inplace crunching (forces -p)")
-	fmt.Println(" -q  : quiet mode")
+// T is the result of crunching a source with New. It reports the same
+// addressing stats the CLI used to print and can be written out with
+// WriteTo.
+type T struct {
+	opt      Options
+	ctx      *crunchCtx
+	crunched []byte
 }
 
-func main() {
-	ctx := crunchCtx{
-		usePrefixArray: true,
-		STATS:          true,
-	}
-	var jmp_str string
-	var jmp_str2 string
-	flag.BoolVar(&ctx.PRG, "p", false, "")
-	flag.BoolVar(&ctx.QUIET, "q", false, "")
-	flag.BoolVar(&ctx.INPLACE, "i", false, "")
-	flag.StringVar(&jmp_str, "x", "", "")
-	flag.BoolVar(&ctx.BLANK, "b", false, "")
-	flag.StringVar(&jmp_str2, "x2", "", "")
-	flag.Usage = usage
-	flag.Parse()
-
-	if jmp_str != "" {
-		ctx.SFX = true
-		ctx.PRG = true
-		ctx.SFXMODE = 0
-	}
-	if jmp_str2 != "" {
-		ctx.SFX = true
-		ctx.PRG = true
-		ctx.SFXMODE = 1
-		jmp_str = jmp_str2
-	}
-	if ctx.INPLACE {
-		ctx.PRG = true
-	}
-	if flag.NArg() != 2 {
-		usage()
-		os.Exit(2)
-	}
-	if ctx.SFX {
-		if len(jmp_str) == 0 {
-			usage()
-			os.Exit(2)
-		}
-		var jmp uint64
-		var err error
-		// Check if the argument starts with '$'
-		if jmp_str[0] == '$' {
-			jmp, err = strconv.ParseUint(jmp_str[1:], 16, 16)
-		} else if len(jmp_str) > 1 && (jmp_str[:2] == "0x" || jmp_str[:2] == "0X") {
-			// Check for the 0x or 0X prefix
-			jmp, err = strconv.ParseUint(jmp_str[2:], 16, 16)
-		} else {
-			// Otherwise, assume it's a decimal value.
-			jmp, err = strconv.ParseUint(jmp_str, 10, 16)
+// New reads src fully and crunches it according to opt.
+func New(opt Options, src io.Reader) (*T, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("tscrunch: read source: %w", err)
+	}
+	ctx, err := newCrunchCtx(opt)
+	if err != nil {
+		return nil, err
+	}
+	crunched, err := crunch(data, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &T{opt: ctx.opt, ctx: ctx, crunched: crunched}, nil
+}
+
+// WriteTo writes the crunched result to w.
+func (t *T) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(t.crunched)
+	return int64(n), err
+}
+
+// DecrunchRange returns the $start-$end address range the decrunched data
+// occupies in memory.
+func (t *T) DecrunchRange() (start, end uint16) {
+	return t.ctx.decrunchTo, t.ctx.decrunchEnd
+}
+
+// LoadRange returns the $start-$end address range the crunched output
+// itself should be loaded at.
+func (t *T) LoadRange() (start, end uint16) {
+	return t.ctx.loadTo, t.ctx.loadTo + uint16(len(t.crunched)) - 1
+}
+
+// PRG reports whether the source was treated as a prg, i.e. had its load
+// address stripped and DecrunchRange/LoadRange populated. This reflects
+// opt.PRG as resolved by New, which forces PRG on for INPLACE and SFX even
+// when the caller didn't set it explicitly.
+func (t *T) PRG() bool {
+	return t.opt.PRG
+}
+
+// SourceLen returns the length in bytes of the (decoded) source that was
+// crunched, prior to PRG header stripping.
+func (t *T) SourceLen() int {
+	return t.ctx.sourceLen
+}
+
+// CrunchedLen returns the length in bytes of the crunched output.
+func (t *T) CrunchedLen() int {
+	return len(t.crunched)
+}
+
+// OptimalRun returns the zero-run length the crunch settled on, i.e. the
+// header byte every non-SFX token stream is prefixed with.
+func (t *T) OptimalRun() int {
+	return t.ctx.optimalRun
+}
+
+// CrunchFiles crunches each of files concurrently, writing each result next
+// to its input as "<file>.lz", bounded to at most concurrency files in
+// flight at once (runtime.NumCPU() if concurrency <= 0). It cancels
+// outstanding work and returns the first error encountered, errgroup-style,
+// instead of only logging, so batch builds can fail fast.
+func CrunchFiles(ctx context.Context, opt Options, files []string, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, file := range files {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
 		}
-		if err != nil {
-			fmt.Printf("Invalid jump address: %v\n", err)
-			usage()
-			os.Exit(2)
+		if ctx.Err() != nil {
+			break
 		}
-		ctx.jmp = uint16(jmp)
-		if ctx.jmp == 0 {
-			usage()
-			os.Exit(2)
+		wg.Add(1)
+		go func(file string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			t1 := time.Now()
+			if err := crunchFileTo(opt, file, file+".lz"); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("%s: %w", file, err)
+					cancel()
+				})
+				return
+			}
+			if !opt.QUIET {
+				fmt.Printf("crunching %q took %s\n\n", file, time.Since(t1))
+			}
+		}(file)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// crunchFileTo crunches inFile and writes the result to outFile. If opt.
+// Progress is set, each sample is tagged with inFile before being reported.
+func crunchFileTo(opt Options, inFile, outFile string) error {
+	in, err := os.Open(inFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if opt.Progress != nil {
+		report := opt.Progress
+		opt.Progress = func(s FileStats) {
+			s.File = inFile
+			report(s)
 		}
 	}
+	t, err := New(opt, in)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = t.WriteTo(out)
+	return err
+}
+
+// Writer is an io.WriteCloser that buffers everything written to it and
+// crunches it as a single unit on Close, mirroring compress/flate's Writer.
+// TSCrunch's optimal parse needs the whole source to produce a minimal
+// encoding, so unlike flate it cannot emit output before Close.
+type Writer struct {
+	w      io.Writer
+	opt    Options
+	buf    bytes.Buffer
+	closed bool
+}
+
+// NewWriter returns a Writer that crunches everything written to it and
+// emits the result to w when Close is called.
+func NewWriter(w io.Writer, opt Options) (*Writer, error) {
+	return &Writer{w: w, opt: opt}, nil
+}
+
+// Write buffers p for crunching on Close.
+func (cw *Writer) Write(p []byte) (int, error) {
+	if cw.closed {
+		return 0, fmt.Errorf("tscrunch: write to closed Writer")
+	}
+	return cw.buf.Write(p)
+}
 
-	ifidx := flag.NArg() - 2
-	ofidx := flag.NArg() - 1
+// Close crunches everything written so far and flushes it to the
+// underlying writer. It is an error to Write after Close.
+func (cw *Writer) Close() error {
+	if cw.closed {
+		return nil
+	}
+	cw.closed = true
+	ctx, err := newCrunchCtx(cw.opt)
+	if err != nil {
+		return err
+	}
+	crunched, err := crunch(cw.buf.Bytes(), ctx)
+	if err != nil {
+		return err
+	}
+	_, err = cw.w.Write(crunched)
+	return err
+}
+
+// Reader decrunches a TSCrunch token stream. See Decode for the one-shot
+// equivalent.
+type Reader struct {
+	r   io.Reader
+	buf *bytes.Reader
+}
 
-	src := load_raw(flag.Args()[ifidx])
-	crunched := crunch(src, &ctx)
-	save_raw(flag.Args()[ofidx], crunched)
+// NewReader reads all of r and prepares to decrunch it. Decrunching only
+// happens lazily on the first Read.
+func NewReader(r io.Reader) (*Reader, error) {
+	return &Reader{r: r}, nil
+}
 
-	if !ctx.QUIET {
-		ratio := (float32(ctx.crunchedSize) * 100.0 / float32(ctx.sourceLen))
-		prg := "RAW"
-		dest_prg := "RAW"
-		if ctx.PRG {
-			prg = "PRG"
+// Read implements io.Reader, returning io.EOF once the decrunched data is
+// exhausted.
+func (cr *Reader) Read(p []byte) (int, error) {
+	if cr.buf == nil {
+		src, err := io.ReadAll(cr.r)
+		if err != nil {
+			return 0, err
 		}
-		if ctx.SFX || ctx.INPLACE {
-			dest_prg = "prg"
+		decoded, err := Decode(src)
+		if err != nil {
+			return 0, err
 		}
-		fmt.Printf("Input file  %s: %s, $%04x - $%04x : %d bytes\n",
-			prg, flag.Args()[ifidx], ctx.decrunchTo, ctx.decrunchEnd, ctx.sourceLen)
-		fmt.Printf("Output file %s: %s, $%04x - $%04x : %d bytes\n",
-			dest_prg, flag.Args()[ofidx], ctx.loadTo, ctx.crunchedSize+int(ctx.loadTo)-1, ctx.crunchedSize)
-		fmt.Printf("Crunched to %.2f%% of original size\n", ratio)
+		cr.buf = bytes.NewReader(decoded)
 	}
+	return cr.buf.Read(p)
 }
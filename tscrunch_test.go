@@ -0,0 +1,122 @@
+package TSCrunch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// corpus returns a handful of inputs chosen to exercise every token type:
+// long runs (RLE/ZERORUN), repeated phrases at short and long distances
+// (LZ2/LZ/LONGLZ), incompressible noise (literals), and a mix of all of
+// them back to back.
+func corpus() [][]byte {
+	rnd := rand.New(rand.NewSource(1))
+
+	noise := make([]byte, 2000)
+	rnd.Read(noise)
+
+	zeros := make([]byte, 1000)
+
+	run := bytes.Repeat([]byte{0x42}, 300)
+
+	phrase := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog, "), 100)
+
+	farRepeat := make([]byte, 20000)
+	rnd.Read(farRepeat[:100])
+	copy(farRepeat[100:], farRepeat[:100])
+	for i := 200; i < len(farRepeat); i += 100 {
+		copy(farRepeat[i:], farRepeat[:100])
+	}
+
+	mixed := append([]byte{}, noise[:500]...)
+	mixed = append(mixed, zeros...)
+	mixed = append(mixed, run...)
+	mixed = append(mixed, phrase...)
+	mixed = append(mixed, noise[500:1000]...)
+
+	return [][]byte{noise, zeros, run, phrase, farRepeat, mixed}
+}
+
+// asPRG prepends a fake 2-byte load address, as -p expects.
+func asPRG(data []byte) []byte {
+	return append([]byte{0x01, 0x08}, data...)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for ci, data := range corpus() {
+		for _, opt := range []Options{
+			{QUIET: true},
+			{QUIET: true, PRG: true},
+			{QUIET: true, PRG: true, INPLACE: true},
+			{QUIET: true, JumpTo: "$0810"},
+			{QUIET: true, JumpTo: "$0810", SFXMODE: 1},
+			{QUIET: true, JumpTo: "$1c20", Platform: C128},
+			{QUIET: true, JumpTo: "$1220", Platform: VIC20_8K},
+			{QUIET: true, JumpTo: "$0420", Platform: PET, SFXMODE: 1},
+		} {
+			ctx, err := newCrunchCtx(opt)
+			if err != nil {
+				t.Fatalf("corpus %d opt %+v: newCrunchCtx: %v", ci, opt, err)
+			}
+			// newCrunchCtx resolves the forced flags (SFX implies PRG,
+			// INPLACE implies PRG); use its view of opt from here on.
+			ropt := ctx.opt
+
+			in := data
+			if ropt.PRG {
+				in = asPRG(data)
+			}
+			// INPLACE needs room at the end for its safety margin; skip
+			// tiny/degenerate inputs that can't satisfy it.
+			if ropt.INPLACE && len(in) < 64 {
+				continue
+			}
+			// asPRG always fakes load address $0801; skip corpus entries
+			// that wouldn't fit below a platform's memTop from there, since
+			// that's a property of the fixture, not of crunch itself.
+			if ropt.SFX && 0x0801+len(data)-1 > int(platforms[ropt.Platform].memTop) {
+				continue
+			}
+
+			crunched, err := crunch(append([]byte{}, in...), ctx)
+			if err != nil {
+				t.Fatalf("corpus %d opt %+v: crunch: %v", ci, ropt, err)
+			}
+
+			if ropt.SFX {
+				// SFX wraps the token stream in a 6502 boot stub meant to
+				// run on (emulated) hardware; only check that it embeds a
+				// decodable stream, using the optimalRun this run picked.
+				boot, _, boot2 := bootSet(ropt.Platform)
+				bootLen := len(boot)
+				if ropt.SFXMODE == 1 {
+					bootLen = len(boot2)
+				}
+				got, _, err := decodeTokens(crunched[bootLen:], ctx.optimalRun)
+				if err != nil {
+					t.Fatalf("corpus %d opt %+v: decodeTokens: %v", ci, ropt, err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Fatalf("corpus %d opt %+v: sfx round-trip mismatch: got %d bytes, want %d", ci, ropt, len(got), len(data))
+				}
+				continue
+			}
+
+			var got []byte
+			want := data // plain PRG mode drops the load address from the stream.
+			if ropt.INPLACE {
+				got, err = DecodeInplace(crunched)
+				want = in // DecodeInplace restores the 2-byte PRG header too.
+			} else {
+				got, err = Decode(crunched)
+			}
+			if err != nil {
+				t.Fatalf("corpus %d opt %+v: decode: %v", ci, ropt, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("corpus %d opt %+v: round-trip mismatch: got %d bytes, want %d", ci, ropt, len(got), len(want))
+			}
+		}
+	}
+}